@@ -3,15 +3,14 @@ package router
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"net/http"
 
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/awslabs/goformation/cloudformation"
 	"github.com/go-openapi/spec"
+	"github.com/sirupsen/logrus"
 )
 
 const apiGatewayIntegrationExtension = "x-amazon-apigateway-integration"
@@ -20,7 +19,8 @@ const apiGatewayAnyMethodExtension = "x-amazon-apigateway-any-method"
 // temporary object. This is just used to marshal and unmarshal the any method
 // API Gateway swagger extension
 type ApiGatewayAnyMethod struct {
-	IntegrationSettings interface{} `json:"x-amazon-apigateway-integration"`
+	IntegrationSettings interface{}           `json:"x-amazon-apigateway-integration"`
+	Security            []map[string][]string `json:"security"`
 }
 
 // AWSServerlessApi wraps GoFormation's AWS::Serverless::Api definition
@@ -28,10 +28,50 @@ type ApiGatewayAnyMethod struct {
 // from the swagger defintion etc.
 type AWSServerlessApi struct {
 	*cloudformation.AWSServerlessApi
+
+	s3Client   s3iface.S3API
+	httpClient *http.Client
+
+	httpBearerToken       string
+	httpBasicAuthUsername string
+	httpBasicAuthPassword string
+
+	logger logrus.FieldLogger
+
+	template *cloudformation.Template
+	symbols  *SymbolTable
+}
+
+// NewAWSServerlessApi wraps a goformation AWS::Serverless::Api resource,
+// applying any given options.
+func NewAWSServerlessApi(resource *cloudformation.AWSServerlessApi, options ...AWSServerlessApiOption) *AWSServerlessApi {
+	api := &AWSServerlessApi{AWSServerlessApi: resource}
+
+	for _, option := range options {
+		option(api)
+	}
+
+	if api.logger == nil {
+		api.logger = logrus.New()
+	}
+
+	return api
+}
+
+// log returns the configured logger, falling back to Logrus's standard
+// logger for an AWSServerlessApi that was built as a struct literal rather
+// than via NewAWSServerlessApi.
+func (api *AWSServerlessApi) log() logrus.FieldLogger {
+	if api.logger == nil {
+		return logrus.StandardLogger()
+	}
+	return api.logger
 }
 
 // Mounts fetches an array of the ServerlessRouterMount's for this API.
 // These contain the path, method and handler function for each mount point.
+// The definition is parsed as OpenAPI 3.0 when its top-level `openapi` key
+// is set, and as Swagger 2.0 otherwise.
 func (api *AWSServerlessApi) Mounts() ([]*ServerlessRouterMount, error) {
 	jsonDefinition, err := api.Swagger()
 
@@ -39,14 +79,31 @@ func (api *AWSServerlessApi) Mounts() ([]*ServerlessRouterMount, error) {
 		// this is our own error so we return it directly
 		return nil, err
 	}
+
+	jsonDefinition, err = api.resolveIntrinsics(jsonDefinition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isOpenAPI3(jsonDefinition) {
+		return api.mountsFromOpenAPI3(jsonDefinition)
+	}
+
+	return api.mountsFromSwagger2(jsonDefinition)
+}
+
+// mountsFromSwagger2 parses a Swagger 2.0 definition into
+// []*ServerlessRouterMount.
+func (api *AWSServerlessApi) mountsFromSwagger2(jsonDefinition []byte) ([]*ServerlessRouterMount, error) {
 	swagger := spec.Swagger{}
-	err = swagger.UnmarshalJSON(jsonDefinition)
+	err := swagger.UnmarshalJSON(jsonDefinition)
 
 	if err != nil {
 		return nil, fmt.Errorf("Cannot parse Swagger definition: %s", err.Error())
 	}
 
 	mounts := []*ServerlessRouterMount{}
+	authorizers := api.parseAuthorizers(&swagger)
 
 	for path, pathItem := range swagger.Paths.Paths {
 		// temporary tracking of mounted methods for the current path. Used to
@@ -69,10 +126,13 @@ func (api *AWSServerlessApi) Mounts() ([]*ServerlessRouterMount, error) {
 				}
 
 				integration, _ := operation.Extensions[apiGatewayIntegrationExtension]
-				mounts = append(mounts, api.createMount(
+				mount := api.createMount(
 					path,
 					strings.ToLower(method),
-					api.parseIntegrationSettings(integration)))
+					api.parseIntegrationSettings(path, method, integration),
+					authorizerForOperation(&operation, authorizers))
+				mount.DefinitionVersion = OpenAPIVersion2
+				mounts = append(mounts, mount)
 				mappedMethods[method] = true
 			}
 		}
@@ -92,12 +152,16 @@ func (api *AWSServerlessApi) Mounts() ([]*ServerlessRouterMount, error) {
 				return nil, fmt.Errorf("Could not unmarshal any method josn to object model")
 			}
 
+			anyMethodAuthorizer := authorizerFromSecurity(anyMethodObject.Security, authorizers)
 			for _, method := range HttpMethods {
 				if _, ok := mappedMethods[method]; !ok {
-					mounts = append(mounts, api.createMount(
+					mount := api.createMount(
 						path,
 						strings.ToLower(method),
-						api.parseIntegrationSettings(anyMethodObject.IntegrationSettings)))
+						api.parseIntegrationSettings(path, method, anyMethodObject.IntegrationSettings),
+						anyMethodAuthorizer)
+					mount.DefinitionVersion = OpenAPIVersion2
+					mounts = append(mounts, mount)
 				}
 			}
 		}
@@ -106,12 +170,28 @@ func (api *AWSServerlessApi) Mounts() ([]*ServerlessRouterMount, error) {
 	return mounts, nil
 }
 
+// isOpenAPI3 reports whether a definition is OpenAPI 3.0 (has a top-level
+// `openapi` key) as opposed to Swagger 2.0 (`swagger`).
+func isOpenAPI3(jsonDefinition []byte) bool {
+	versionProbe := struct {
+		OpenAPI string `json:"openapi"`
+	}{}
+
+	if err := json.Unmarshal(jsonDefinition, &versionProbe); err != nil {
+		return false
+	}
+
+	return versionProbe.OpenAPI != ""
+}
+
 // parses a byte[] for the API Gateway inetegration extension from a method and return
 // the object representation
-func (api *AWSServerlessApi) parseIntegrationSettings(integrationData interface{}) *ApiGatewayIntegration {
+func (api *AWSServerlessApi) parseIntegrationSettings(path string, method string, integrationData interface{}) *ApiGatewayIntegration {
+	logger := api.log().WithFields(logrus.Fields{"path": path, "method": method})
+
 	integrationJson, err := json.Marshal(integrationData)
 	if err != nil {
-		log.Printf("Could not parse integration data to json")
+		logger.WithError(err).Debugf("Could not parse integration data to json: %+v", integrationData)
 		return nil
 	}
 
@@ -119,32 +199,42 @@ func (api *AWSServerlessApi) parseIntegrationSettings(integrationData interface{
 	err = json.Unmarshal(integrationJson, &integration)
 
 	if err != nil {
-		log.Printf("Could not unmarshal integration data to ApiGatewayIntegration model")
+		logger.WithError(err).Debugf("Could not unmarshal integration data to ApiGatewayIntegration model: %s", integrationJson)
 		return nil
 	}
 
 	return &integration
 }
 
-func (api *AWSServerlessApi) createMount(path string, verb string, integration *ApiGatewayIntegration) *(ServerlessRouterMount) {
+func (api *AWSServerlessApi) createMount(path string, verb string, integration *ApiGatewayIntegration, authorizer *Authorizer) *(ServerlessRouterMount) {
 	newMount := &ServerlessRouterMount{
-		Name:   path,
-		Path:   path,
-		Method: verb,
+		Name:       path,
+		Path:       path,
+		Method:     verb,
+		Authorizer: authorizer,
 	}
 
+	logger := api.log().WithFields(logrus.Fields{"path": path, "method": verb})
+
 	if integration == nil {
-		log.Printf("No integration defined for method")
+		logger.Debug("No integration defined for method")
 		return newMount
 	}
 
+	newMount.Integration = integration
+	logger = logger.WithField("integrationType", integration.Type)
+
 	functionName, err := integration.GetFunctionArn()
 
 	if err != nil {
-		log.Printf("Could not extract Lambda function ARN: %s", err.Error())
+		logger.WithError(err).Warn("Could not extract Lambda function ARN")
+	} else {
+		logger = logger.WithField("functionArn", functionName)
 	}
 	newMount.IntegrationArn = functionName
 
+	logger.Debug("Mounted route")
+
 	return newMount
 }
 
@@ -157,6 +247,7 @@ func (api *AWSServerlessApi) Swagger() ([]byte, error) {
 	// 1. A definition URI defined as a string
 	if api.DefinitionUri != nil {
 		if api.DefinitionUri.String != nil {
+			api.log().WithField("swaggerSource", "uri").Debug("Fetching Swagger definition")
 			return api.getSwaggerFromURI(*api.DefinitionUri.String)
 		}
 	}
@@ -164,6 +255,7 @@ func (api *AWSServerlessApi) Swagger() ([]byte, error) {
 	// 2. A definition URI defined as an S3 Location
 	if api.DefinitionUri != nil {
 		if api.DefinitionUri.S3Location != nil {
+			api.log().WithField("swaggerSource", "s3").Debug("Fetching Swagger definition")
 			return api.getSwaggerFromS3Location(*api.DefinitionUri.S3Location)
 		}
 	}
@@ -174,10 +266,12 @@ func (api *AWSServerlessApi) Swagger() ([]byte, error) {
 
 		case string:
 			// 3. A definition body defined as JSON (which will unmarshal to a string)
+			api.log().WithField("swaggerSource", "inline-json").Debug("Fetching Swagger definition")
 			return api.getSwaggerFromString(val)
 
 		case map[string]interface{}:
 			// 4. A definition body defined as YAML (which will unmarshal to map[string]interface{})
+			api.log().WithField("swaggerSource", "inline-yaml").Debug("Fetching Swagger definition")
 			return api.getSwaggerFromMap(val)
 		}
 
@@ -187,39 +281,6 @@ func (api *AWSServerlessApi) Swagger() ([]byte, error) {
 
 }
 
-func (api *AWSServerlessApi) getSwaggerFromURI(uri string) ([]byte, error) {
-	data, err := ioutil.ReadFile(uri)
-	if err != nil {
-		return nil, fmt.Errorf("Cannot read local Swagger definition (%s): %s", uri, err.Error())
-	}
-	return data, nil
-}
-
-func (api *AWSServerlessApi) getSwaggerFromS3Location(loc cloudformation.AWSServerlessApi_S3Location) ([]byte, error) {
-	sess := session.Must(session.NewSession())
-	client := s3.New(sess)
-
-	objectVersion := string(loc.Version)
-	s3Input := s3.GetObjectInput{
-		Bucket:    &loc.Bucket,
-		Key:       &loc.Key,
-		VersionId: &objectVersion,
-	}
-
-	object, err := client.GetObject(&s3Input)
-
-	if err != nil {
-		return nil, fmt.Errorf("Error while fetching Swagger template from S3: %s\n%s", loc.Bucket+loc.Key, err.Error())
-	}
-
-	body, err := ioutil.ReadAll(object.Body)
-
-	if err != nil {
-		return nil, fmt.Errorf("Cannot read s3 Swagger boject body: %s", err.Error())
-	}
-	return body, nil
-}
-
 func (api *AWSServerlessApi) getSwaggerFromString(input string) ([]byte, error) {
 	return []byte(input), nil
 }
@@ -0,0 +1,334 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+const apiGatewayAuthorizerExtension = "x-amazon-apigateway-authorizer"
+
+// Supported values for Authorizer.Type.
+const (
+	AuthorizerTypeToken            = "token"
+	AuthorizerTypeRequest          = "request"
+	AuthorizerTypeCognitoUserPools = "cognito_user_pools"
+)
+
+// ErrUnauthorized is returned when an authorizer could not identify the
+// caller at all (maps to an HTTP 401).
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is returned when an authorizer identified the caller but its
+// policy denies the requested method (maps to an HTTP 403).
+var ErrForbidden = errors.New("forbidden")
+
+// Authorizer models the x-amazon-apigateway-authorizer swagger extension
+// referenced from a securityDefinitions entry.
+type Authorizer struct {
+	Name                         string
+	Type                         string   `json:"type"`
+	AuthorizerUri                string   `json:"authorizerUri"`
+	IdentitySource               string   `json:"identitySource"`
+	IdentityValidationExpression string   `json:"identityValidationExpression"`
+	AuthorizerResultTtlInSeconds int      `json:"authorizerResultTtlInSeconds"`
+	ProviderARNs                 []string `json:"providerARNs"`
+}
+
+// AuthorizerPolicyStatement is a single statement of the IAM policy document
+// an authorizer Lambda returns.
+type AuthorizerPolicyStatement struct {
+	Effect   string      `json:"Effect"`
+	Action   interface{} `json:"Action"`
+	Resource interface{} `json:"Resource"`
+}
+
+// AuthorizerPolicyDocument is the IAM policy document an authorizer Lambda
+// returns alongside the principal identifier and request context.
+type AuthorizerPolicyDocument struct {
+	Version   string                      `json:"Version"`
+	Statement []AuthorizerPolicyStatement `json:"Statement"`
+}
+
+// AuthorizerResult is the response shape an authorizer Lambda is expected to
+// return.
+type AuthorizerResult struct {
+	PrincipalID    string                   `json:"principalId"`
+	PolicyDocument AuthorizerPolicyDocument `json:"policyDocument"`
+	Context        map[string]interface{}   `json:"context"`
+}
+
+// Allows reports whether the policy document grants access to methodArn,
+// i.e. there is at least one "Allow" statement whose resource matches and no
+// "Deny" statement that also matches. Explicit denies win, matching IAM
+// evaluation order.
+func (policy *AuthorizerPolicyDocument) Allows(methodArn string) bool {
+	allowed := false
+
+	for _, statement := range policy.Statement {
+		if !resourceMatches(statement.Resource, methodArn) {
+			continue
+		}
+
+		switch statement.Effect {
+		case "Deny":
+			return false
+		case "Allow":
+			allowed = true
+		}
+	}
+
+	return allowed
+}
+
+func resourceMatches(resource interface{}, methodArn string) bool {
+	switch val := resource.(type) {
+	case string:
+		return arnPatternMatches(val, methodArn)
+	case []interface{}:
+		for _, entry := range val {
+			if s, ok := entry.(string); ok && arnPatternMatches(s, methodArn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// arnPatternMatches matches an IAM resource ARN pattern (which may contain
+// '*' and '?' wildcards) against a concrete method ARN. Unlike path.Match,
+// '*' here matches any run of characters including '/', since IAM/API
+// Gateway resource wildcards are expected to span the whole resource path
+// (e.g. ".../GET/*" matching ".../GET/pets/1").
+func arnPatternMatches(pattern string, methodArn string) bool {
+	matched, err := regexp.MatchString("^"+arnPatternToRegexp(pattern)+"$", methodArn)
+	return err == nil && matched
+}
+
+// arnPatternToRegexp translates an IAM resource ARN pattern into an
+// equivalent regexp pattern, escaping everything except the '*' and '?'
+// wildcards.
+func arnPatternToRegexp(pattern string) string {
+	var result strings.Builder
+
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			result.WriteString(".*")
+		case '?':
+			result.WriteString(".")
+		default:
+			result.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return result.String()
+}
+
+// authorizerCacheEntry is a cached authorizer result along with its expiry.
+type authorizerCacheEntry struct {
+	result    *AuthorizerResult
+	expiresAt time.Time
+}
+
+// AuthorizerCache caches authorizer results for AuthorizerResultTtlInSeconds,
+// keyed by the raw identity source value, so repeated requests from the same
+// caller don't re-invoke the authorizer Lambda on every call.
+type AuthorizerCache struct {
+	mu      sync.Mutex
+	entries map[string]authorizerCacheEntry
+}
+
+// NewAuthorizerCache creates an empty AuthorizerCache.
+func NewAuthorizerCache() *AuthorizerCache {
+	return &AuthorizerCache{entries: map[string]authorizerCacheEntry{}}
+}
+
+func (cache *AuthorizerCache) get(key string) (*AuthorizerResult, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (cache *AuthorizerCache) put(key string, result *AuthorizerResult, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries[key] = authorizerCacheEntry{result: result, expiresAt: time.Now().Add(ttl)}
+}
+
+// AuthorizerInvoker invokes an authorizer Lambda function with the given
+// event and returns its parsed result. The HTTP dispatcher supplies an
+// implementation backed by the local Lambda runtime.
+type AuthorizerInvoker interface {
+	InvokeAuthorizer(functionArn string, event map[string]interface{}) (*AuthorizerResult, error)
+}
+
+// Authorize resolves the identity source from req, invokes (or reuses a
+// cached result from) the authorizer Lambda, and evaluates the returned
+// policy against methodArn. On success it returns the authorizer result so
+// the caller can inject PrincipalID/Context into the downstream event's
+// requestContext.authorizer. It returns ErrUnauthorized when no identity
+// could be resolved, and ErrForbidden when the policy denies methodArn.
+func (authorizer *Authorizer) Authorize(invoker AuthorizerInvoker, cache *AuthorizerCache, req *http.Request, methodArn string) (*AuthorizerResult, error) {
+	identity, ok := authorizer.resolveIdentity(req)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	if cache != nil {
+		if cached, hit := cache.get(identity); hit {
+			if !cached.PolicyDocument.Allows(methodArn) {
+				return nil, ErrForbidden
+			}
+			return cached, nil
+		}
+	}
+
+	functionArn, err := authorizerFunctionArn(authorizer.AuthorizerUri)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := invoker.InvokeAuthorizer(functionArn, authorizer.buildEvent(req, identity, methodArn))
+	if err != nil {
+		return nil, fmt.Errorf("authorizer Lambda invocation failed: %s", err.Error())
+	}
+
+	if cache != nil {
+		cache.put(identity, result, time.Duration(authorizer.AuthorizerResultTtlInSeconds)*time.Second)
+	}
+
+	if !result.PolicyDocument.Allows(methodArn) {
+		return nil, ErrForbidden
+	}
+
+	return result, nil
+}
+
+// resolveIdentity reads the identitySource (a method.request.header.* or
+// method.request.querystring.* reference, or a comma-separated list of them
+// for REQUEST authorizers) from the incoming request.
+func (authorizer *Authorizer) resolveIdentity(req *http.Request) (string, bool) {
+	sources := strings.Split(authorizer.IdentitySource, ",")
+	values := make([]string, 0, len(sources))
+
+	for _, source := range sources {
+		value, ok := resolveMethodRequestParameter(req, nil, strings.TrimSpace(source))
+		if !ok {
+			continue
+		}
+		values = append(values, value)
+	}
+
+	if len(values) == 0 {
+		return "", false
+	}
+	return strings.Join(values, "|"), true
+}
+
+func (authorizer *Authorizer) buildEvent(req *http.Request, identity string, methodArn string) map[string]interface{} {
+	if authorizer.Type == AuthorizerTypeToken {
+		return map[string]interface{}{
+			"type":               "TOKEN",
+			"authorizationToken": identity,
+			"methodArn":          methodArn,
+		}
+	}
+
+	headers := map[string]string{}
+	for key := range req.Header {
+		headers[key] = req.Header.Get(key)
+	}
+
+	return map[string]interface{}{
+		"type":       "REQUEST",
+		"methodArn":  methodArn,
+		"headers":    headers,
+		"path":       req.URL.Path,
+		"httpMethod": req.Method,
+	}
+}
+
+func authorizerFunctionArn(uri string) (string, error) {
+	integration := ApiGatewayIntegration{Uri: uri}
+	return integration.GetFunctionArn()
+}
+
+// parseAuthorizers reads swagger's securityDefinitions and returns the
+// authorizers declared via x-amazon-apigateway-authorizer, keyed by their
+// security definition name.
+func (api *AWSServerlessApi) parseAuthorizers(swagger *spec.Swagger) map[string]*Authorizer {
+	authorizers := map[string]*Authorizer{}
+
+	for name, scheme := range swagger.SecurityDefinitions {
+		raw, ok := scheme.Extensions[apiGatewayAuthorizerExtension]
+		if !ok {
+			continue
+		}
+
+		if authorizer := api.parseAuthorizerExtension(name, raw); authorizer != nil {
+			authorizers[name] = authorizer
+		}
+	}
+
+	return authorizers
+}
+
+// parseAuthorizerExtension unmarshals a raw x-amazon-apigateway-authorizer
+// extension value into an Authorizer, logging and returning nil on failure.
+func (api *AWSServerlessApi) parseAuthorizerExtension(name string, raw interface{}) *Authorizer {
+	logger := api.log().WithField("authorizer", name)
+
+	authorizerJson, err := json.Marshal(raw)
+	if err != nil {
+		logger.WithError(err).Debugf("Could not parse authorizer to json: %+v", raw)
+		return nil
+	}
+
+	authorizer := Authorizer{}
+	if err := json.Unmarshal(authorizerJson, &authorizer); err != nil {
+		logger.WithError(err).Debugf("Could not unmarshal authorizer: %s", authorizerJson)
+		return nil
+	}
+	authorizer.Name = name
+	return &authorizer
+}
+
+// authorizerForOperation returns the first authorizer referenced by the
+// operation's security requirements that is present in authorizers, or nil
+// if the operation has no matching security requirement.
+func authorizerForOperation(operation *spec.Operation, authorizers map[string]*Authorizer) *Authorizer {
+	return authorizerFromSecurity(operation.Security, authorizers)
+}
+
+// authorizerFromSecurity returns the first authorizer referenced by a raw
+// `security` requirements list (as used by both per-operation security and
+// the x-amazon-apigateway-any-method extension) that is present in
+// authorizers, or nil if none match.
+func authorizerFromSecurity(security []map[string][]string, authorizers map[string]*Authorizer) *Authorizer {
+	for _, requirement := range security {
+		for name := range requirement {
+			if authorizer, ok := authorizers[name]; ok {
+				return authorizer
+			}
+		}
+	}
+	return nil
+}
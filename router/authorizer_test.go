@@ -0,0 +1,195 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestArnPatternMatchesAcrossSlashes(t *testing.T) {
+	pattern := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/*"
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets/1"
+
+	if !arnPatternMatches(pattern, methodArn) {
+		t.Fatalf("expected wildcard %q to match %q across '/'", pattern, methodArn)
+	}
+}
+
+func TestArnPatternMatchesDoesNotMatchUnrelatedResource(t *testing.T) {
+	pattern := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets/*"
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/POST/pets"
+
+	if arnPatternMatches(pattern, methodArn) {
+		t.Fatalf("did not expect %q to match %q", pattern, methodArn)
+	}
+}
+
+func allowStatement(resource string) AuthorizerPolicyStatement {
+	return AuthorizerPolicyStatement{Effect: "Allow", Resource: resource}
+}
+
+func denyStatement(resource string) AuthorizerPolicyStatement {
+	return AuthorizerPolicyStatement{Effect: "Deny", Resource: resource}
+}
+
+func TestAuthorizerPolicyDocumentAllowsMatchingAllow(t *testing.T) {
+	policy := AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{allowStatement("arn:aws:execute-api:*:*:*/*/GET/pets")}}
+
+	if !policy.Allows("arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets") {
+		t.Fatal("expected a matching Allow statement to grant access")
+	}
+}
+
+func TestAuthorizerPolicyDocumentDeniesWithoutMatchingStatement(t *testing.T) {
+	policy := AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{allowStatement("arn:aws:execute-api:*:*:*/*/GET/pets")}}
+
+	if policy.Allows("arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/POST/pets") {
+		t.Fatal("expected no matching statement to deny access")
+	}
+}
+
+func TestAuthorizerPolicyDocumentExplicitDenyWinsOverAllow(t *testing.T) {
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets"
+	policy := AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{
+		allowStatement("arn:aws:execute-api:*:*:*/*/GET/*"),
+		denyStatement(methodArn),
+	}}
+
+	if policy.Allows(methodArn) {
+		t.Fatal("expected an explicit Deny to win over a matching Allow")
+	}
+}
+
+func TestAuthorizerCacheExpiry(t *testing.T) {
+	cache := NewAuthorizerCache()
+	result := &AuthorizerResult{PrincipalID: "user-1"}
+
+	cache.put("identity", result, 10*time.Millisecond)
+
+	if _, ok := cache.get("identity"); !ok {
+		t.Fatal("expected a freshly cached entry to be present")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.get("identity"); ok {
+		t.Fatal("expected the cached entry to have expired")
+	}
+}
+
+func TestAuthorizerCacheNonPositiveTTLIsNotCached(t *testing.T) {
+	cache := NewAuthorizerCache()
+	cache.put("identity", &AuthorizerResult{PrincipalID: "user-1"}, 0)
+
+	if _, ok := cache.get("identity"); ok {
+		t.Fatal("expected a zero TTL not to be cached")
+	}
+}
+
+// countingInvoker is a stub AuthorizerInvoker that always returns the same
+// result and counts how many times it was invoked, so tests can assert on
+// cache hit/miss behavior.
+type countingInvoker struct {
+	result *AuthorizerResult
+	calls  int
+}
+
+func (invoker *countingInvoker) InvokeAuthorizer(functionArn string, event map[string]interface{}) (*AuthorizerResult, error) {
+	invoker.calls++
+	return invoker.result, nil
+}
+
+func tokenAuthRequest(token string) *http.Request {
+	return &http.Request{
+		Header: http.Header{"Authorization": []string{token}},
+		URL:    &url.URL{Path: "/pets"},
+	}
+}
+
+func TestAuthorizeInvokesLambdaOnCacheMiss(t *testing.T) {
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets"
+	authorizer := &Authorizer{
+		Type:                         AuthorizerTypeToken,
+		AuthorizerUri:                "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyAuth/invocations",
+		IdentitySource:               "method.request.header.Authorization",
+		AuthorizerResultTtlInSeconds: 300,
+	}
+	invoker := &countingInvoker{result: &AuthorizerResult{
+		PrincipalID:    "user-1",
+		PolicyDocument: AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{allowStatement(methodArn)}},
+	}}
+	cache := NewAuthorizerCache()
+
+	result, err := authorizer.Authorize(invoker, cache, tokenAuthRequest("Bearer abc"), methodArn)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if result.PrincipalID != "user-1" {
+		t.Fatalf("unexpected principal: %s", result.PrincipalID)
+	}
+	if invoker.calls != 1 {
+		t.Fatalf("expected 1 invocation, got %d", invoker.calls)
+	}
+}
+
+func TestAuthorizeReusesCachedResult(t *testing.T) {
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets"
+	authorizer := &Authorizer{
+		Type:                         AuthorizerTypeToken,
+		AuthorizerUri:                "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyAuth/invocations",
+		IdentitySource:               "method.request.header.Authorization",
+		AuthorizerResultTtlInSeconds: 300,
+	}
+	invoker := &countingInvoker{result: &AuthorizerResult{
+		PrincipalID:    "user-1",
+		PolicyDocument: AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{allowStatement(methodArn)}},
+	}}
+	cache := NewAuthorizerCache()
+
+	if _, err := authorizer.Authorize(invoker, cache, tokenAuthRequest("Bearer abc"), methodArn); err != nil {
+		t.Fatalf("unexpected error on first call: %s", err.Error())
+	}
+	if _, err := authorizer.Authorize(invoker, cache, tokenAuthRequest("Bearer abc"), methodArn); err != nil {
+		t.Fatalf("unexpected error on second call: %s", err.Error())
+	}
+
+	if invoker.calls != 1 {
+		t.Fatalf("expected the second call to reuse the cached result, got %d invocations", invoker.calls)
+	}
+}
+
+func TestAuthorizeReturnsForbiddenWhenPolicyDenies(t *testing.T) {
+	methodArn := "arn:aws:execute-api:us-east-1:123456789012:abcd1234/prod/GET/pets"
+	authorizer := &Authorizer{
+		Type:           AuthorizerTypeToken,
+		AuthorizerUri:  "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyAuth/invocations",
+		IdentitySource: "method.request.header.Authorization",
+	}
+	invoker := &countingInvoker{result: &AuthorizerResult{
+		PrincipalID:    "user-1",
+		PolicyDocument: AuthorizerPolicyDocument{Statement: []AuthorizerPolicyStatement{denyStatement(methodArn)}},
+	}}
+
+	_, err := authorizer.Authorize(invoker, NewAuthorizerCache(), tokenAuthRequest("Bearer abc"), methodArn)
+	if err != ErrForbidden {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestAuthorizeReturnsUnauthorizedWhenIdentityMissing(t *testing.T) {
+	authorizer := &Authorizer{
+		Type:           AuthorizerTypeToken,
+		AuthorizerUri:  "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyAuth/invocations",
+		IdentitySource: "method.request.header.Authorization",
+	}
+	invoker := &countingInvoker{result: &AuthorizerResult{}}
+
+	_, err := authorizer.Authorize(invoker, NewAuthorizerCache(), &http.Request{Header: http.Header{}, URL: &url.URL{}}, "methodArn")
+	if err != ErrUnauthorized {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if invoker.calls != 0 {
+		t.Fatalf("expected no Lambda invocation without an identity, got %d", invoker.calls)
+	}
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// inputParamPattern matches a VTL `$input.params('name')` accessor, capturing
+// the raw method request parameter name being looked up.
+var inputParamPattern = regexp.MustCompile(`\$input\.params\('([^']+)'\)`)
+
+// BuildInvocationEvent produces the payload to hand to the mount's Lambda
+// function for an incoming request. AWS_PROXY/HTTP_PROXY integrations (and
+// mounts with no integration at all) pass the raw request body through
+// untouched, matching real API Gateway. For the non-proxy aws/http
+// integration types it renders the matching requestTemplates entry, which is
+// how API Gateway itself shapes the Lambda input for those integration
+// types.
+func (mount *ServerlessRouterMount) BuildInvocationEvent(req *http.Request, pathParams map[string]string, body []byte) ([]byte, error) {
+	if mount.Integration == nil || mount.Integration.IsProxy() {
+		return body, nil
+	}
+
+	template, ok := mount.Integration.RequestTemplateFor(req.Header.Get("Content-Type"))
+	if !ok {
+		return body, nil
+	}
+
+	rendered, err := renderRequestTemplate(template, req, pathParams, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not render request template for %s %s: %s", mount.Method, mount.Path, err.Error())
+	}
+	return rendered, nil
+}
+
+// renderRequestTemplate is a minimal VTL-like renderer covering the
+// `$input.params('name')` and `$input.body` accessors SAM templates most
+// commonly use in requestTemplates. It does not implement the full Velocity
+// Template Language. `$input.params('name')` is resolved directly against
+// the method request's path, querystring and header values, matching real
+// API Gateway's $input.params() behavior, rather than through any
+// requestParameters mapping (that mapping only governs the
+// integration-side parameters sent alongside the rendered template).
+func renderRequestTemplate(template string, req *http.Request, pathParams map[string]string, body []byte) ([]byte, error) {
+	var marshalErr error
+
+	rendered := inputParamPattern.ReplaceAllStringFunc(template, func(match string) string {
+		name := inputParamPattern.FindStringSubmatch(match)[1]
+
+		escaped, err := json.Marshal(resolveInputParam(req, pathParams, name))
+		if err != nil {
+			marshalErr = err
+			return match
+		}
+		return string(escaped)
+	})
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	rendered = strings.ReplaceAll(rendered, "$input.body", string(body))
+
+	return []byte(rendered), nil
+}
+
+// resolveInputParam looks up a raw method request parameter name against the
+// incoming request, checking path parameters, then the querystring, then
+// headers, mirroring API Gateway's own $input.params() precedence.
+func resolveInputParam(req *http.Request, pathParams map[string]string, name string) string {
+	if value, ok := pathParams[name]; ok {
+		return value
+	}
+	if value := req.URL.Query().Get(name); value != "" {
+		return value
+	}
+	return req.Header.Get(name)
+}
+
+// ShapeResponse selects the mount's integration responses entry matching
+// the Lambda invocation's outcome (its error message for a failure, or
+// "default" for a success), and returns the HTTP status code and that
+// entry's responseParameters, keyed with the "method.response." prefix
+// removed (e.g. "header.X") and with their mapping expressions returned
+// unevaluated (a static value keeps its single quotes). ok is false when
+// the integration is a proxy integration or defines no matching responses
+// entry, in which case callers should fall back to a plain passthrough of
+// the Lambda's own response.
+func (mount *ServerlessRouterMount) ShapeResponse(selectionSubject string) (statusCode int, headers map[string]string, ok bool) {
+	if mount.Integration == nil || mount.Integration.IsProxy() {
+		return 0, nil, false
+	}
+
+	response := mount.Integration.MatchResponse(selectionSubject)
+	if response == nil {
+		return 0, nil, false
+	}
+
+	statusCode = http.StatusOK
+	if response.StatusCode != "" {
+		if parsed, err := strconv.Atoi(response.StatusCode); err == nil {
+			statusCode = parsed
+		}
+	}
+
+	headers = map[string]string{}
+	for headerRef, valueRef := range response.ResponseParameters {
+		headers[stripRequestParameterPrefix(headerRef)] = valueRef
+	}
+
+	return statusCode, headers, true
+}
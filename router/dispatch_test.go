@@ -0,0 +1,158 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func newTestRequest(method string, query string, headers map[string]string) *http.Request {
+	req := &http.Request{
+		Method: method,
+		Header: http.Header{},
+		URL:    &url.URL{Path: "/pets", RawQuery: query},
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return req
+}
+
+func TestResolveRequestParameters(t *testing.T) {
+	integration := &ApiGatewayIntegration{
+		RequestParameters: map[string]string{
+			"integration.request.header.X-Pet-Id": "method.request.path.id",
+			"integration.request.header.X-Absent": "method.request.header.Missing",
+		},
+	}
+
+	resolved := integration.ResolveRequestParameters(newTestRequest("GET", "", nil), map[string]string{"id": "123"})
+
+	if resolved["header.X-Pet-Id"] != "123" {
+		t.Fatalf("expected path param to resolve, got %q", resolved["header.X-Pet-Id"])
+	}
+	if _, ok := resolved["header.X-Absent"]; ok {
+		t.Fatal("did not expect an unresolvable parameter to be present")
+	}
+}
+
+func TestRequestTemplateForFallsBackToApplicationJson(t *testing.T) {
+	integration := &ApiGatewayIntegration{
+		RequestTemplates: map[string]string{"application/json": `{"id": $input.params('id')}`},
+	}
+
+	template, ok := integration.RequestTemplateFor("text/plain")
+	if !ok {
+		t.Fatal("expected a fallback to application/json")
+	}
+	if template != `{"id": $input.params('id')}` {
+		t.Fatalf("unexpected template: %s", template)
+	}
+}
+
+func TestMatchResponseFallsBackToDefault(t *testing.T) {
+	notFound := &ApiGatewayIntegrationResponse{StatusCode: "404"}
+	defaultResponse := &ApiGatewayIntegrationResponse{StatusCode: "200"}
+	integration := &ApiGatewayIntegration{
+		Responses: map[string]*ApiGatewayIntegrationResponse{
+			"Not Found.*": notFound,
+			"default":     defaultResponse,
+		},
+	}
+
+	if response := integration.MatchResponse("Not Found: no such pet"); response != notFound {
+		t.Fatalf("expected the Not Found pattern to match, got %+v", response)
+	}
+	if response := integration.MatchResponse("some other error"); response != defaultResponse {
+		t.Fatalf("expected fallback to default, got %+v", response)
+	}
+}
+
+func TestBuildInvocationEventPassesProxyBodyThrough(t *testing.T) {
+	mount := &ServerlessRouterMount{Integration: &ApiGatewayIntegration{Type: IntegrationTypeAwsProxy}}
+
+	event, err := mount.BuildInvocationEvent(newTestRequest("GET", "", nil), nil, []byte(`{"raw":true}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if string(event) != `{"raw":true}` {
+		t.Fatalf("expected proxy integration to pass the body through untouched, got %s", event)
+	}
+}
+
+func TestBuildInvocationEventRendersNonProxyTemplate(t *testing.T) {
+	mount := &ServerlessRouterMount{
+		Integration: &ApiGatewayIntegration{
+			Type: IntegrationTypeAws,
+			RequestTemplates: map[string]string{
+				"application/json": `{"id": $input.params('id'), "body": $input.body}`,
+			},
+		},
+	}
+
+	event, err := mount.BuildInvocationEvent(newTestRequest("GET", "", map[string]string{"Content-Type": "application/json"}), map[string]string{"id": "42"}, []byte(`{"name":"fido"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := `{"id": "42", "body": {"name":"fido"}}`
+	if string(event) != expected {
+		t.Fatalf("expected %s, got %s", expected, event)
+	}
+}
+
+func TestBuildInvocationEventResolvesQuerystringAndHeaderParams(t *testing.T) {
+	mount := &ServerlessRouterMount{
+		Integration: &ApiGatewayIntegration{
+			Type: IntegrationTypeAws,
+			RequestTemplates: map[string]string{
+				"application/json": `{"name": $input.params('name'), "token": $input.params('X-Token')}`,
+			},
+		},
+	}
+
+	req := newTestRequest("GET", "name=fido", map[string]string{"Content-Type": "application/json", "X-Token": "abc"})
+
+	event, err := mount.BuildInvocationEvent(req, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	expected := `{"name": "fido", "token": "abc"}`
+	if string(event) != expected {
+		t.Fatalf("expected %s, got %s", expected, event)
+	}
+}
+
+func TestShapeResponseBuildsStatusAndHeaders(t *testing.T) {
+	mount := &ServerlessRouterMount{
+		Integration: &ApiGatewayIntegration{
+			Type: IntegrationTypeAws,
+			Responses: map[string]*ApiGatewayIntegrationResponse{
+				"Not Found.*": {
+					StatusCode:         "404",
+					ResponseParameters: map[string]string{"method.response.header.X-Error": "'not found'"},
+				},
+			},
+		},
+	}
+
+	statusCode, headers, ok := mount.ShapeResponse("Not Found: no such pet")
+	if !ok {
+		t.Fatal("expected a matching response entry")
+	}
+	if statusCode != 404 {
+		t.Fatalf("expected 404, got %d", statusCode)
+	}
+	if headers["header.X-Error"] != "'not found'" {
+		t.Fatalf("unexpected headers: %+v", headers)
+	}
+}
+
+func TestShapeResponseIsNoopForProxyIntegrations(t *testing.T) {
+	mount := &ServerlessRouterMount{Integration: &ApiGatewayIntegration{Type: IntegrationTypeAwsProxy}}
+
+	if _, _, ok := mount.ShapeResponse("anything"); ok {
+		t.Fatal("did not expect a proxy integration to shape the response")
+	}
+}
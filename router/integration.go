@@ -0,0 +1,147 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Supported values for ApiGatewayIntegration.Type, as documented for
+// x-amazon-apigateway-integration.
+const (
+	IntegrationTypeAws       = "aws"
+	IntegrationTypeAwsProxy  = "aws_proxy"
+	IntegrationTypeHttp      = "http"
+	IntegrationTypeHttpProxy = "http_proxy"
+	IntegrationTypeMock      = "mock"
+)
+
+// functionArnFromUriPattern matches the Lambda sub-resource ARN embedded in
+// an integration's invocation URI, e.g.
+// arn:aws:apigateway:{region}:lambda:path/2015-03-31/functions/{functionArn}/invocations
+var functionArnFromUriPattern = regexp.MustCompile(`/functions/([^/]+)/invocations$`)
+
+// ApiGatewayIntegrationResponse represents a single entry of the `responses`
+// map on an x-amazon-apigateway-integration object, keyed by the Lambda
+// response selection pattern (or "default").
+type ApiGatewayIntegrationResponse struct {
+	StatusCode         string            `json:"statusCode"`
+	ResponseTemplates  map[string]string `json:"responseTemplates"`
+	ResponseParameters map[string]string `json:"responseParameters"`
+}
+
+// ApiGatewayIntegration models the x-amazon-apigateway-integration swagger
+// extension, covering the fields API Gateway itself supports for aws,
+// aws_proxy, http, http_proxy and mock integrations.
+type ApiGatewayIntegration struct {
+	Type                string                                    `json:"type"`
+	HttpMethod          string                                    `json:"httpMethod"`
+	Uri                 string                                    `json:"uri"`
+	Credentials         string                                    `json:"credentials"`
+	PassthroughBehavior string                                    `json:"passthroughBehavior"`
+	ContentHandling     string                                    `json:"contentHandling"`
+	RequestTemplates    map[string]string                         `json:"requestTemplates"`
+	RequestParameters   map[string]string                         `json:"requestParameters"`
+	Responses           map[string]*ApiGatewayIntegrationResponse `json:"responses"`
+}
+
+// IsProxy returns true for aws_proxy and http_proxy integrations, where API
+// Gateway passes the request through untouched rather than applying
+// requestTemplates/requestParameters/responses.
+func (integration *ApiGatewayIntegration) IsProxy() bool {
+	return integration.Type == IntegrationTypeAwsProxy || integration.Type == IntegrationTypeHttpProxy
+}
+
+// GetFunctionArn extracts the Lambda function ARN this integration invokes
+// from its invocation URI.
+func (integration *ApiGatewayIntegration) GetFunctionArn() (string, error) {
+	matches := functionArnFromUriPattern.FindStringSubmatch(integration.Uri)
+	if len(matches) != 2 {
+		return "", fmt.Errorf("could not find a Lambda function ARN in integration uri: %s", integration.Uri)
+	}
+	return matches[1], nil
+}
+
+// ResolveRequestParameters evaluates this integration's requestParameters
+// mapping (e.g. "integration.request.header.X": "method.request.querystring.Y")
+// against an incoming HTTP request, returning the resolved
+// integration-side parameter values keyed with the "integration.request."
+// prefix removed (e.g. "header.X").
+func (integration *ApiGatewayIntegration) ResolveRequestParameters(req *http.Request, pathParams map[string]string) map[string]string {
+	resolved := map[string]string{}
+
+	for integrationParam, methodParam := range integration.RequestParameters {
+		value, ok := resolveMethodRequestParameter(req, pathParams, methodParam)
+		if !ok {
+			continue
+		}
+		resolved[stripRequestParameterPrefix(integrationParam)] = value
+	}
+
+	return resolved
+}
+
+// resolveMethodRequestParameter reads a single "method.request.*" reference
+// (header, querystring or path) from the incoming request.
+func resolveMethodRequestParameter(req *http.Request, pathParams map[string]string, ref string) (string, bool) {
+	parts := strings.SplitN(ref, ".", 3)
+	if len(parts) != 3 || parts[0] != "method" || parts[1] != "request" {
+		return "", false
+	}
+
+	kind := parts[2]
+	switch {
+	case strings.HasPrefix(kind, "header."):
+		value := req.Header.Get(strings.TrimPrefix(kind, "header."))
+		return value, value != ""
+	case strings.HasPrefix(kind, "querystring."):
+		value := req.URL.Query().Get(strings.TrimPrefix(kind, "querystring."))
+		return value, value != ""
+	case strings.HasPrefix(kind, "path."):
+		value, ok := pathParams[strings.TrimPrefix(kind, "path.")]
+		return value, ok
+	default:
+		return "", false
+	}
+}
+
+// stripRequestParameterPrefix trims the "integration.request." (or
+// "method.response.") prefix from a requestParameters/responseParameters key,
+// leaving e.g. "header.X".
+func stripRequestParameterPrefix(ref string) string {
+	parts := strings.SplitN(ref, ".", 3)
+	if len(parts) != 3 {
+		return ref
+	}
+	return parts[2]
+}
+
+// RequestTemplateFor returns the VTL mapping template registered for the
+// given content type, falling back to the "application/json" template, and
+// reports whether one was found.
+func (integration *ApiGatewayIntegration) RequestTemplateFor(contentType string) (string, bool) {
+	if template, ok := integration.RequestTemplates[contentType]; ok {
+		return template, true
+	}
+	template, ok := integration.RequestTemplates["application/json"]
+	return template, ok
+}
+
+// MatchResponse finds the responses entry whose selection pattern matches
+// the Lambda error/result, falling back to "default". Returns nil if no
+// responses are configured.
+func (integration *ApiGatewayIntegration) MatchResponse(selectionSubject string) *ApiGatewayIntegrationResponse {
+	for pattern, response := range integration.Responses {
+		if pattern == "default" {
+			continue
+		}
+		if matched, err := regexp.MatchString(pattern, selectionSubject); err == nil && matched {
+			return response
+		}
+	}
+	if response, ok := integration.Responses["default"]; ok {
+		return response
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Local defaults used when resolving Fn::GetAtt against a Lambda function
+// resource, matching the synthetic ARNs "sam local" hands out for functions
+// it invokes in-process.
+const (
+	localAWSAccountID = "123456789012"
+	localAWSRegion    = "us-east-1"
+)
+
+var subPlaceholderPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// SymbolTable holds the values substituted into a template's `Ref`/`Fn::Sub`
+// intrinsics while resolving a Swagger/OpenAPI definition, keyed by
+// CloudFormation parameter name. It is exposed on AWSServerlessApi so
+// callers can pre-seed overrides, mirroring how `sam local` already accepts
+// `--parameter-overrides`.
+type SymbolTable struct {
+	Parameters map[string]string
+}
+
+// NewSymbolTable creates an empty SymbolTable.
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{Parameters: map[string]string{}}
+}
+
+// WithParameterOverride sets a single parameter override and returns the
+// table for chaining.
+func (table *SymbolTable) WithParameterOverride(name string, value string) *SymbolTable {
+	table.Parameters[name] = value
+	return table
+}
+
+// Symbols returns the symbol table used to resolve `Ref`/`Fn::Sub`
+// intrinsics, creating one on first access.
+func (api *AWSServerlessApi) Symbols() *SymbolTable {
+	if api.symbols == nil {
+		api.symbols = NewSymbolTable()
+	}
+	return api.symbols
+}
+
+// resolveIntrinsics evaluates Ref/Fn::Sub/Fn::GetAtt/Fn::Join intrinsics in
+// the raw definition bytes against the parent template's Resources and
+// Parameters (and any overrides pre-seeded via Symbols()), then dereferences
+// local swagger `$ref: "#/..."` pointers, so that the parser sees fully
+// inlined objects.
+func (api *AWSServerlessApi) resolveIntrinsics(raw []byte) ([]byte, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("Cannot parse definition for intrinsic resolution: %s", err.Error())
+	}
+
+	resolved := api.resolveValue(decoded)
+	resolved = resolveLocalRefs(resolved, resolved, 0)
+
+	result, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot re-marshal definition after intrinsic resolution: %s", err.Error())
+	}
+	return result, nil
+}
+
+// resolveValue walks decoded JSON, evaluating any CloudFormation intrinsic
+// function object it finds and recursing into everything else.
+func (api *AWSServerlessApi) resolveValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if len(val) == 1 {
+			for key, arg := range val {
+				switch key {
+				case "Ref":
+					if name, ok := arg.(string); ok {
+						return api.resolveRef(name)
+					}
+				case "Fn::Sub":
+					return api.resolveSub(arg)
+				case "Fn::GetAtt":
+					return api.resolveGetAtt(arg)
+				case "Fn::Join":
+					return api.resolveJoin(arg)
+				}
+			}
+		}
+
+		resolved := make(map[string]interface{}, len(val))
+		for key, child := range val {
+			resolved[key] = api.resolveValue(child)
+		}
+		return resolved
+
+	case []interface{}:
+		resolved := make([]interface{}, len(val))
+		for i, child := range val {
+			resolved[i] = api.resolveValue(child)
+		}
+		return resolved
+
+	default:
+		return value
+	}
+}
+
+// resolveRef resolves a `Ref` to a parameter override, the parent
+// template's declared parameter default, the local value of the
+// AWS::Region/AWS::AccountId pseudo parameters, or (for a resource logical ID
+// declared in the parent template's Resources) the logical ID itself,
+// which is how `sam local` already identifies Serverless resources. A name
+// that matches none of these is left unresolved and returned as-is, so a
+// typo'd Ref surfaces as an obviously wrong value rather than being
+// silently swallowed.
+func (api *AWSServerlessApi) resolveRef(name string) string {
+	if override, ok := api.Symbols().Parameters[name]; ok {
+		return override
+	}
+
+	if api.template != nil {
+		if param, ok := api.template.Parameters[name].(map[string]interface{}); ok {
+			if def, ok := param["Default"].(string); ok {
+				return def
+			}
+		}
+	}
+
+	switch name {
+	case "AWS::Region":
+		return localAWSRegion
+	case "AWS::AccountId":
+		return localAWSAccountID
+	}
+
+	if api.template != nil {
+		if _, ok := api.template.Resources[name]; !ok {
+			api.log().WithField("ref", name).Warn("Ref does not match a declared parameter or resource; using the logical ID as-is")
+		}
+	}
+
+	return name
+}
+
+// resolveGetAtt resolves `Fn::GetAtt: [LogicalId, Attribute]` (or its
+// shorthand string form "LogicalId.Attribute"). Only the `Arn` attribute of
+// a function is meaningful locally, and resolves to the synthetic ARN sam
+// local uses to route invocations to that function.
+func (api *AWSServerlessApi) resolveGetAtt(arg interface{}) interface{} {
+	logicalID, attribute, ok := getAttParts(arg)
+	if !ok {
+		return arg
+	}
+
+	if attribute == "Arn" {
+		return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", localAWSRegion, localAWSAccountID, logicalID)
+	}
+
+	return logicalID
+}
+
+func getAttParts(arg interface{}) (logicalID string, attribute string, ok bool) {
+	switch val := arg.(type) {
+	case string:
+		parts := strings.SplitN(val, ".", 2)
+		if len(parts) != 2 {
+			return "", "", false
+		}
+		return parts[0], parts[1], true
+
+	case []interface{}:
+		if len(val) != 2 {
+			return "", "", false
+		}
+		logicalID, ok1 := val[0].(string)
+		attribute, ok2 := val[1].(string)
+		return logicalID, attribute, ok1 && ok2
+	}
+
+	return "", "", false
+}
+
+// resolveSub resolves the string form of `Fn::Sub`, replacing each
+// "${Name}" placeholder with the Ref/GetAtt resolution of Name. The
+// (string, variables-map) form of Fn::Sub is not supported.
+func (api *AWSServerlessApi) resolveSub(arg interface{}) interface{} {
+	template, ok := arg.(string)
+	if !ok {
+		return arg
+	}
+
+	return subPlaceholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := strings.TrimSuffix(strings.TrimPrefix(placeholder, "${"), "}")
+
+		if strings.Contains(name, ".") {
+			resolved := api.resolveGetAtt(name)
+			if str, ok := resolved.(string); ok {
+				return str
+			}
+			return placeholder
+		}
+
+		return api.resolveRef(name)
+	})
+}
+
+// resolveJoin resolves `Fn::Join: [delimiter, [values...]]`.
+func (api *AWSServerlessApi) resolveJoin(arg interface{}) interface{} {
+	parts, ok := arg.([]interface{})
+	if !ok || len(parts) != 2 {
+		return arg
+	}
+
+	delimiter, ok := parts[0].(string)
+	if !ok {
+		return arg
+	}
+
+	values, ok := parts[1].([]interface{})
+	if !ok {
+		return arg
+	}
+
+	resolvedValues := make([]string, 0, len(values))
+	for _, value := range values {
+		resolved := api.resolveValue(value)
+		if str, ok := resolved.(string); ok {
+			resolvedValues = append(resolvedValues, str)
+		}
+	}
+
+	return strings.Join(resolvedValues, delimiter)
+}
+
+// maxRefDepth bounds how many nested "$ref" pointers resolveLocalRefs will
+// follow, guarding against a cyclic definition.
+const maxRefDepth = 32
+
+// resolveLocalRefs dereferences local JSON pointer `$ref: "#/..."` objects
+// against root, so spec.Operation and friends see fully inlined objects
+// instead of opaque reference maps.
+func resolveLocalRefs(value interface{}, root interface{}, depth int) interface{} {
+	if depth > maxRefDepth {
+		return value
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		if len(val) == 1 {
+			if ref, ok := val["$ref"].(string); ok && strings.HasPrefix(ref, "#/") {
+				target, found := jsonPointerLookup(root, ref)
+				if found {
+					return resolveLocalRefs(target, root, depth+1)
+				}
+				return value
+			}
+		}
+
+		resolved := make(map[string]interface{}, len(val))
+		for key, child := range val {
+			resolved[key] = resolveLocalRefs(child, root, depth)
+		}
+		return resolved
+
+	case []interface{}:
+		resolved := make([]interface{}, len(val))
+		for i, child := range val {
+			resolved[i] = resolveLocalRefs(child, root, depth)
+		}
+		return resolved
+
+	default:
+		return value
+	}
+}
+
+// jsonPointerLookup resolves a "#/a/b/c" JSON pointer against root.
+func jsonPointerLookup(root interface{}, pointer string) (interface{}, bool) {
+	segments := strings.Split(strings.TrimPrefix(pointer, "#/"), "/")
+
+	current := root
+	for _, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+
+		container, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = container[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/awslabs/goformation/cloudformation"
+)
+
+func TestResolveRefPrefersParameterOverride(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+	api.Symbols().WithParameterOverride("Stage", "prod")
+
+	if resolved := api.resolveRef("Stage"); resolved != "prod" {
+		t.Fatalf("expected override to win, got %q", resolved)
+	}
+}
+
+func TestResolveRefFallsBackToTemplateParameterDefault(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithTemplate(&cloudformation.Template{
+		Parameters: map[string]interface{}{
+			"Stage": map[string]interface{}{"Type": "String", "Default": "dev"},
+		},
+	}))
+
+	if resolved := api.resolveRef("Stage"); resolved != "dev" {
+		t.Fatalf("expected the template's parameter default, got %q", resolved)
+	}
+}
+
+func TestResolveRefResolvesPseudoParameters(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	if resolved := api.resolveRef("AWS::Region"); resolved != localAWSRegion {
+		t.Fatalf("expected %q, got %q", localAWSRegion, resolved)
+	}
+	if resolved := api.resolveRef("AWS::AccountId"); resolved != localAWSAccountID {
+		t.Fatalf("expected %q, got %q", localAWSAccountID, resolved)
+	}
+}
+
+func TestResolveRefReturnsLogicalIdForDeclaredResource(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithTemplate(&cloudformation.Template{
+		Resources: cloudformation.Resources{"MyFunction": &cloudformation.AWSServerlessFunction{}},
+	}))
+
+	if resolved := api.resolveRef("MyFunction"); resolved != "MyFunction" {
+		t.Fatalf("expected the logical ID to be returned unchanged, got %q", resolved)
+	}
+}
+
+func TestResolveRefReturnsNameForUnknownRef(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithTemplate(&cloudformation.Template{
+		Resources: cloudformation.Resources{"MyFunction": &cloudformation.AWSServerlessFunction{}},
+	}))
+
+	if resolved := api.resolveRef("NotDeclaredAnywhere"); resolved != "NotDeclaredAnywhere" {
+		t.Fatalf("expected an unresolvable Ref to fall back to its bare name, got %q", resolved)
+	}
+}
+
+func TestResolveGetAttBuildsSyntheticFunctionArn(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	resolved := api.resolveGetAtt("MyFunction.Arn")
+	expected := "arn:aws:lambda:us-east-1:123456789012:function:MyFunction"
+	if resolved != expected {
+		t.Fatalf("expected %q, got %v", expected, resolved)
+	}
+}
+
+func TestResolveGetAttListFormResolvesOtherAttributesToLogicalId(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	resolved := api.resolveGetAtt([]interface{}{"MyFunction", "Name"})
+	if resolved != "MyFunction" {
+		t.Fatalf("expected the logical ID for a non-Arn attribute, got %v", resolved)
+	}
+}
+
+func TestResolveSubSubstitutesRefAndGetAttPlaceholders(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+	api.Symbols().WithParameterOverride("Stage", "prod")
+
+	resolved := api.resolveSub("arn:aws:apigateway:${AWS::Region}:lambda:path/2015-03-31/functions/${MyFunction.Arn}/invocations")
+
+	expected := "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyFunction/invocations"
+	if resolved != expected {
+		t.Fatalf("expected %q, got %v", expected, resolved)
+	}
+}
+
+func TestResolveJoinJoinsResolvedValues(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	resolved := api.resolveJoin([]interface{}{"/", []interface{}{"prod", "pets"}})
+	if resolved != "prod/pets" {
+		t.Fatalf("expected %q, got %v", "prod/pets", resolved)
+	}
+}
+
+func TestResolveIntrinsicsEndToEnd(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithTemplate(&cloudformation.Template{
+		Resources: cloudformation.Resources{"MyFunction": &cloudformation.AWSServerlessFunction{}},
+	}))
+
+	definition := []byte(`{
+		"swagger": "2.0",
+		"paths": {
+			"/pets": {
+				"get": {
+					"x-amazon-apigateway-integration": {
+						"type": "aws_proxy",
+						"uri": {"Fn::Sub": "arn:aws:apigateway:${AWS::Region}:lambda:path/2015-03-31/functions/${MyFunction.Arn}/invocations"}
+					}
+				}
+			}
+		}
+	}`)
+
+	resolved, err := api.resolveIntrinsics(definition)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(resolved, &decoded); err != nil {
+		t.Fatalf("could not parse resolved definition: %s", err.Error())
+	}
+
+	uri := decoded["paths"].(map[string]interface{})["/pets"].(map[string]interface{})["get"].(map[string]interface{})["x-amazon-apigateway-integration"].(map[string]interface{})["uri"]
+	expected := "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyFunction/invocations"
+	if uri != expected {
+		t.Fatalf("expected %q, got %v", expected, uri)
+	}
+}
+
+func TestJsonPointerLookupEscapesTildeAndSlash(t *testing.T) {
+	root := map[string]interface{}{
+		"a/b": map[string]interface{}{
+			"c~d": "found",
+		},
+	}
+
+	value, ok := jsonPointerLookup(root, "#/a~1b/c~0d")
+	if !ok {
+		t.Fatal("expected the escaped pointer to resolve")
+	}
+	if value != "found" {
+		t.Fatalf("expected %q, got %v", "found", value)
+	}
+}
+
+func TestResolveLocalRefsGuardsAgainstCycles(t *testing.T) {
+	root := map[string]interface{}{
+		"a": map[string]interface{}{"$ref": "#/b"},
+		"b": map[string]interface{}{"$ref": "#/a"},
+	}
+
+	resolved := resolveLocalRefs(root["a"], root, 0)
+
+	if _, ok := resolved.(map[string]interface{})["$ref"]; !ok {
+		t.Fatalf("expected cyclic refs to bottom out rather than recurse forever, got %v", resolved)
+	}
+}
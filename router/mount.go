@@ -0,0 +1,39 @@
+package router
+
+// HttpMethods is the list of HTTP verbs that Mounts() will look for on each
+// Swagger/OpenAPI path item.
+var HttpMethods = []string{
+	"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH",
+}
+
+// ServerlessRouterMount describes a single routable path+method combination
+// extracted from an API definition, along with the integration that should
+// be invoked when a request matches it.
+type ServerlessRouterMount struct {
+	Name   string
+	Path   string
+	Method string
+
+	// IntegrationArn is the Lambda function ARN the integration resolves to.
+	// Kept alongside Integration for callers that only care about the ARN.
+	IntegrationArn string
+
+	// Integration is the full parsed x-amazon-apigateway-integration object
+	// for this mount, or nil if the method/path has no integration.
+	Integration *ApiGatewayIntegration
+
+	// Authorizer is the x-amazon-apigateway-authorizer referenced by this
+	// operation's security requirements, or nil if the method is not
+	// protected by a custom/Cognito authorizer.
+	Authorizer *Authorizer
+
+	// DefinitionVersion records which API definition format this mount was
+	// parsed from, OpenAPIVersion2 or OpenAPIVersion3.
+	DefinitionVersion string
+}
+
+// Supported values for ServerlessRouterMount.DefinitionVersion.
+const (
+	OpenAPIVersion2 = "2.0"
+	OpenAPIVersion3 = "3.0"
+)
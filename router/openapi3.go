@@ -0,0 +1,108 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mountsFromOpenAPI3 parses an OpenAPI 3.0 definition into the same
+// []*ServerlessRouterMount shape mountsFromSwagger2 produces, so callers
+// don't need to care which definition format a template shipped with.
+func (api *AWSServerlessApi) mountsFromOpenAPI3(jsonDefinition []byte) ([]*ServerlessRouterMount, error) {
+	doc, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(jsonDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse OpenAPI 3.0 definition: %s", err.Error())
+	}
+
+	mounts := []*ServerlessRouterMount{}
+	authorizers := api.parseOpenAPI3Authorizers(doc)
+
+	for path, pathItem := range doc.Paths {
+		mappedMethods := map[string]bool{}
+
+		for method, operation := range pathItem.Operations() {
+			integrationData, ok := operation.Extensions[apiGatewayIntegrationExtension]
+			if !ok {
+				continue
+			}
+
+			mount := api.createMount(
+				path,
+				strings.ToLower(method),
+				api.parseIntegrationSettings(path, method, integrationData),
+				authorizerFromSecurity(openAPI3SecurityRequirements(operation.Security), authorizers))
+			mount.DefinitionVersion = OpenAPIVersion3
+			mounts = append(mounts, mount)
+			mappedMethods[strings.ToUpper(method)] = true
+		}
+
+		anyMethod, available := pathItem.Extensions[apiGatewayAnyMethodExtension]
+		if available {
+			anyMethodJson, err := json.Marshal(anyMethod)
+			if err != nil {
+				return nil, fmt.Errorf("Could not marshal any method object to json")
+			}
+
+			anyMethodObject := ApiGatewayAnyMethod{}
+			if err := json.Unmarshal(anyMethodJson, &anyMethodObject); err != nil {
+				return nil, fmt.Errorf("Could not unmarshal any method json to object model")
+			}
+
+			anyMethodAuthorizer := authorizerFromSecurity(anyMethodObject.Security, authorizers)
+			for _, method := range HttpMethods {
+				if _, ok := mappedMethods[method]; !ok {
+					mount := api.createMount(
+						path,
+						strings.ToLower(method),
+						api.parseIntegrationSettings(path, method, anyMethodObject.IntegrationSettings),
+						anyMethodAuthorizer)
+					mount.DefinitionVersion = OpenAPIVersion3
+					mounts = append(mounts, mount)
+				}
+			}
+		}
+	}
+
+	return mounts, nil
+}
+
+// parseOpenAPI3Authorizers reads doc's components.securitySchemes and
+// returns the authorizers declared via x-amazon-apigateway-authorizer,
+// keyed by their security scheme name.
+func (api *AWSServerlessApi) parseOpenAPI3Authorizers(doc *openapi3.Swagger) map[string]*Authorizer {
+	authorizers := map[string]*Authorizer{}
+
+	for name, schemeRef := range doc.Components.SecuritySchemes {
+		if schemeRef.Value == nil {
+			continue
+		}
+
+		raw, ok := schemeRef.Value.Extensions[apiGatewayAuthorizerExtension]
+		if !ok {
+			continue
+		}
+
+		if authorizer := api.parseAuthorizerExtension(name, raw); authorizer != nil {
+			authorizers[name] = authorizer
+		}
+	}
+
+	return authorizers
+}
+
+// openAPI3SecurityRequirements converts kin-openapi's SecurityRequirements
+// into the []map[string][]string shape authorizerFromSecurity expects.
+func openAPI3SecurityRequirements(security *openapi3.SecurityRequirements) []map[string][]string {
+	if security == nil {
+		return nil
+	}
+
+	requirements := make([]map[string][]string, len(*security))
+	for i, requirement := range *security {
+		requirements[i] = map[string][]string(requirement)
+	}
+	return requirements
+}
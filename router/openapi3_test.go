@@ -0,0 +1,111 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/awslabs/goformation/cloudformation"
+)
+
+const openAPI3Definition = `{
+  "openapi": "3.0.0",
+  "info": {"title": "pets", "version": "1.0"},
+  "components": {
+    "securitySchemes": {
+      "TokenAuth": {
+        "type": "apiKey",
+        "name": "Authorization",
+        "in": "header",
+        "x-amazon-apigateway-authorizer": {
+          "type": "token",
+          "authorizerUri": "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:MyAuth/invocations",
+          "identitySource": "method.request.header.Authorization",
+          "authorizerResultTtlInSeconds": 300
+        }
+      }
+    }
+  },
+  "paths": {
+    "/pets": {
+      "get": {
+        "security": [{"TokenAuth": []}],
+        "x-amazon-apigateway-integration": {
+          "type": "aws_proxy",
+          "httpMethod": "POST",
+          "uri": "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:ListPets/invocations"
+        },
+        "responses": {"200": {"description": "OK"}}
+      },
+      "x-amazon-apigateway-any-method": {
+        "x-amazon-apigateway-integration": {
+          "type": "aws_proxy",
+          "httpMethod": "POST",
+          "uri": "arn:aws:apigateway:us-east-1:lambda:path/2015-03-31/functions/arn:aws:lambda:us-east-1:123456789012:function:AnyPets/invocations"
+        }
+      }
+    }
+  }
+}`
+
+func TestMountsFromOpenAPI3ParsesExplicitMethod(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	mounts, err := api.mountsFromOpenAPI3([]byte(openAPI3Definition))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var get *ServerlessRouterMount
+	for _, mount := range mounts {
+		if mount.Method == "get" {
+			get = mount
+		}
+	}
+	if get == nil {
+		t.Fatal("expected a mount for GET /pets")
+	}
+	if get.DefinitionVersion != OpenAPIVersion3 {
+		t.Fatalf("expected DefinitionVersion %q, got %q", OpenAPIVersion3, get.DefinitionVersion)
+	}
+	if get.IntegrationArn != "arn:aws:lambda:us-east-1:123456789012:function:ListPets" {
+		t.Fatalf("unexpected integration ARN: %s", get.IntegrationArn)
+	}
+	if get.Authorizer == nil || get.Authorizer.Name != "TokenAuth" {
+		t.Fatalf("expected the GET method's security requirement to resolve the TokenAuth authorizer, got %+v", get.Authorizer)
+	}
+}
+
+func TestMountsFromOpenAPI3FillsInAnyMethodForUnmappedVerbs(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	mounts, err := api.mountsFromOpenAPI3([]byte(openAPI3Definition))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var post *ServerlessRouterMount
+	for _, mount := range mounts {
+		if mount.Method == "post" {
+			post = mount
+		}
+	}
+	if post == nil {
+		t.Fatal("expected the any-method extension to mount POST /pets")
+	}
+	if post.IntegrationArn != "arn:aws:lambda:us-east-1:123456789012:function:AnyPets" {
+		t.Fatalf("unexpected integration ARN: %s", post.IntegrationArn)
+	}
+}
+
+func TestMountsFromOpenAPI3RejectsInvalidDefinition(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{})
+
+	if _, err := api.mountsFromOpenAPI3([]byte("not json")); err == nil {
+		t.Fatal("expected an error for a malformed OpenAPI 3.0 definition")
+	}
+}
+
+func TestOpenAPI3SecurityRequirementsReturnsNilForNoSecurity(t *testing.T) {
+	if requirements := openAPI3SecurityRequirements(nil); requirements != nil {
+		t.Fatalf("expected nil requirements, got %+v", requirements)
+	}
+}
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/awslabs/goformation/cloudformation"
+	"github.com/sirupsen/logrus"
+)
+
+// AWSServerlessApiOption configures optional dependencies on an
+// AWSServerlessApi, such as an injected S3 client or HTTP client for
+// fetching a Swagger/OpenAPI definition.
+type AWSServerlessApiOption func(*AWSServerlessApi)
+
+// WithS3Client overrides the S3 client used to fetch swagger definitions
+// from an S3 location, letting tests inject a stub instead of a real
+// session.
+func WithS3Client(client s3iface.S3API) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.s3Client = client
+	}
+}
+
+// WithHTTPClient overrides the HTTP client used to fetch swagger
+// definitions from an http(s):// definition URI.
+func WithHTTPClient(client *http.Client) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.httpClient = client
+	}
+}
+
+// WithHTTPBearerToken sends an `Authorization: Bearer <token>` header when
+// fetching swagger definitions over HTTP(S).
+func WithHTTPBearerToken(token string) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.httpBearerToken = token
+	}
+}
+
+// WithHTTPBasicAuth sends HTTP Basic auth credentials when fetching swagger
+// definitions over HTTP(S).
+func WithHTTPBasicAuth(username string, password string) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.httpBasicAuthUsername = username
+		api.httpBasicAuthPassword = password
+	}
+}
+
+// WithLogger overrides the structured logger used for routing/integration
+// decisions, letting callers pipe JSON logs to an aggregator. Defaults to a
+// Logrus text logger when not supplied.
+func WithLogger(logger logrus.FieldLogger) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.logger = logger
+	}
+}
+
+// WithTemplate gives AWSServerlessApi access to the parent CloudFormation
+// template's Resources and Parameters, so Ref/Fn::Sub/Fn::GetAtt/Fn::Join
+// intrinsics embedded in the inline DefinitionBody can be resolved before
+// mounting.
+func WithTemplate(template *cloudformation.Template) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		api.template = template
+	}
+}
+
+// WithParameterOverrides pre-seeds the symbol table used to resolve `Ref`
+// intrinsics, matching how `sam local` already accepts
+// `--parameter-overrides`.
+func WithParameterOverrides(overrides map[string]string) AWSServerlessApiOption {
+	return func(api *AWSServerlessApi) {
+		for name, value := range overrides {
+			api.Symbols().WithParameterOverride(name, value)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/awslabs/goformation/cloudformation"
+)
+
+// defaultHTTPFetchTimeout bounds how long fetching a Swagger/OpenAPI
+// definition from an http(s):// URI is allowed to take when no HTTP client
+// was injected via WithHTTPClient.
+const defaultHTTPFetchTimeout = 30 * time.Second
+
+// s3MaxRetries and s3RetryBaseDelay bound the exponential backoff applied to
+// transient (5xx) S3 errors when fetching a definition from S3.
+const s3MaxRetries = 3
+const s3RetryBaseDelay = 200 * time.Millisecond
+
+func (api *AWSServerlessApi) getSwaggerFromURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		return api.getSwaggerFromHTTP(uri)
+	}
+
+	data, err := ioutil.ReadFile(uri)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read local Swagger definition (%s): %s", uri, err.Error())
+	}
+	return data, nil
+}
+
+func (api *AWSServerlessApi) getSwaggerFromHTTP(uri string) ([]byte, error) {
+	client := api.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: defaultHTTPFetchTimeout}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot build request for Swagger definition (%s): %s", uri, err.Error())
+	}
+
+	if api.httpBearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+api.httpBearerToken)
+	} else if api.httpBasicAuthUsername != "" {
+		req.SetBasicAuth(api.httpBasicAuthUsername, api.httpBasicAuthPassword)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot fetch Swagger definition (%s): %s", uri, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Cannot fetch Swagger definition (%s): unexpected status %s", uri, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read Swagger definition response (%s): %s", uri, err.Error())
+	}
+	return body, nil
+}
+
+func (api *AWSServerlessApi) getSwaggerFromS3Location(loc cloudformation.AWSServerlessApi_S3Location) ([]byte, error) {
+	client := api.s3Client
+	if client == nil {
+		client = s3.New(session.Must(session.NewSession()))
+	}
+
+	s3Input := s3.GetObjectInput{
+		Bucket: &loc.Bucket,
+		Key:    &loc.Key,
+	}
+	if loc.Version != 0 {
+		objectVersion := strconv.Itoa(loc.Version)
+		s3Input.VersionId = &objectVersion
+	}
+
+	var object *s3.GetObjectOutput
+	var err error
+
+	delay := s3RetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		object, err = client.GetObject(&s3Input)
+		if err == nil {
+			break
+		}
+
+		awsErr, ok := err.(awserr.Error)
+		if !ok {
+			return nil, fmt.Errorf("Error while fetching Swagger template from S3 (%s/%s): %s", loc.Bucket, loc.Key, err.Error())
+		}
+
+		switch awsErr.Code() {
+		case s3.ErrCodeNoSuchKey:
+			return nil, fmt.Errorf("Swagger template not found in S3: no such key %s/%s", loc.Bucket, loc.Key)
+		case s3.ErrCodeNoSuchBucket:
+			return nil, fmt.Errorf("Swagger template not found in S3: no such bucket %s", loc.Bucket)
+		case "AccessDenied":
+			return nil, fmt.Errorf("Access denied fetching Swagger template from S3: %s/%s", loc.Bucket, loc.Key)
+		}
+
+		if !isTransientS3Error(awsErr) || attempt >= s3MaxRetries {
+			return nil, fmt.Errorf("Error while fetching Swagger template from S3 (%s): %s", awsErr.Code(), awsErr.Message())
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	body, err := ioutil.ReadAll(object.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read s3 Swagger object body: %s", err.Error())
+	}
+	return body, nil
+}
+
+// isTransientS3Error reports whether an S3 error is a transient 5xx
+// response worth retrying, as opposed to a permanent client error.
+func isTransientS3Error(awsErr awserr.Error) bool {
+	if reqErr, ok := awsErr.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() >= 500
+	}
+	return false
+}
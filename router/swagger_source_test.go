@@ -0,0 +1,139 @@
+package router
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/awslabs/goformation/cloudformation"
+)
+
+// stubS3Client implements s3iface.S3API, returning a canned GetObject
+// response/error sequence so tests don't need real AWS credentials.
+type stubS3Client struct {
+	s3iface.S3API
+
+	responses []stubS3Response
+	calls     int
+}
+
+type stubS3Response struct {
+	output *s3.GetObjectOutput
+	err    error
+}
+
+func (stub *stubS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	response := stub.responses[stub.calls]
+	if stub.calls < len(stub.responses)-1 {
+		stub.calls++
+	}
+	return response.output, response.err
+}
+
+func transientS3Error() awserr.Error {
+	return awserr.NewRequestFailure(
+		awserr.New("InternalError", "please try again", nil),
+		http.StatusInternalServerError,
+		"request-id")
+}
+
+func TestGetSwaggerFromS3LocationNoSuchKey(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(&stubS3Client{
+		responses: []stubS3Response{{err: awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)}},
+	}))
+
+	_, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"})
+	if err == nil {
+		t.Fatal("expected an error for a missing key")
+	}
+}
+
+func TestGetSwaggerFromS3LocationNoSuchBucket(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(&stubS3Client{
+		responses: []stubS3Response{{err: awserr.New(s3.ErrCodeNoSuchBucket, "not found", nil)}},
+	}))
+
+	_, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"})
+	if err == nil {
+		t.Fatal("expected an error for a missing bucket")
+	}
+}
+
+func TestGetSwaggerFromS3LocationAccessDenied(t *testing.T) {
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(&stubS3Client{
+		responses: []stubS3Response{{err: awserr.New("AccessDenied", "nope", nil)}},
+	}))
+
+	_, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"})
+	if err == nil {
+		t.Fatal("expected an error for access denied")
+	}
+}
+
+func TestGetSwaggerFromS3LocationRetriesTransientErrors(t *testing.T) {
+	stub := &stubS3Client{
+		responses: []stubS3Response{
+			{err: transientS3Error()},
+			{err: transientS3Error()},
+			{output: &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"swagger":"2.0"}`)))}},
+		},
+	}
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(stub))
+
+	body, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"})
+	if err != nil {
+		t.Fatalf("expected eventual success after retrying transient errors, got: %s", err.Error())
+	}
+	if string(body) != `{"swagger":"2.0"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+func TestGetSwaggerFromS3LocationGivesUpAfterMaxRetries(t *testing.T) {
+	responses := make([]stubS3Response, 0, s3MaxRetries+2)
+	for i := 0; i < s3MaxRetries+2; i++ {
+		responses = append(responses, stubS3Response{err: transientS3Error()})
+	}
+
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(&stubS3Client{responses: responses}))
+
+	_, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"})
+	if err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+}
+
+func TestGetSwaggerFromS3LocationOmitsVersionIdWhenEmpty(t *testing.T) {
+	var capturedInput *s3.GetObjectInput
+	stub := &recordingS3Client{
+		onGetObject: func(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+			capturedInput = input
+			return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader([]byte(`{}`)))}, nil
+		},
+	}
+	api := NewAWSServerlessApi(&cloudformation.AWSServerlessApi{}, WithS3Client(stub))
+
+	if _, err := api.getSwaggerFromS3Location(cloudformation.AWSServerlessApi_S3Location{Bucket: "my-bucket", Key: "swagger.json"}); err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	if capturedInput.VersionId != nil {
+		t.Fatalf("expected VersionId to be omitted, got %q", *capturedInput.VersionId)
+	}
+}
+
+// recordingS3Client implements s3iface.S3API by delegating GetObject to a
+// closure, letting tests assert on the request that was built.
+type recordingS3Client struct {
+	s3iface.S3API
+
+	onGetObject func(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+func (client *recordingS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return client.onGetObject(input)
+}